@@ -0,0 +1,150 @@
+package nkn_sdk_go
+
+import (
+	"encoding/binary"
+	"strings"
+	"time"
+
+	"github.com/gogo/protobuf/proto"
+	bolt "go.etcd.io/bbolt"
+
+	"github.com/nknorg/nkn-sdk-go/payloads"
+)
+
+var (
+	boltSeenBucket    = []byte("seen")
+	boltPendingBucket = []byte("pending")
+)
+
+// BoltMessageStore is a MessageStore backed by a BoltDB file, so inbound
+// dedup state and outbound delivery state survive a process restart. This
+// is what makes AtLeastOnce/ExactlyOnce delivery actually resumable for a
+// mobile client that gets killed and restarted, rather than just retried
+// within a single process lifetime like MemoryMessageStore.
+type BoltMessageStore struct {
+	db *bolt.DB
+}
+
+// NewBoltMessageStore opens (creating if necessary) a BoltDB-backed
+// MessageStore at path.
+func NewBoltMessageStore(path string) (*BoltMessageStore, error) {
+	db, err := bolt.Open(path, 0600, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	err = db.Update(func(tx *bolt.Tx) error {
+		if _, err := tx.CreateBucketIfNotExists(boltSeenBucket); err != nil {
+			return err
+		}
+		if _, err := tx.CreateBucketIfNotExists(boltPendingBucket); err != nil {
+			return err
+		}
+		return nil
+	})
+	if err != nil {
+		db.Close()
+		return nil, err
+	}
+
+	return &BoltMessageStore{db: db}, nil
+}
+
+// Close closes the underlying BoltDB file.
+func (s *BoltMessageStore) Close() error {
+	return s.db.Close()
+}
+
+func (s *BoltMessageStore) Seen(pid []byte) bool {
+	var seen bool
+	_ = s.db.View(func(tx *bolt.Tx) error {
+		v := tx.Bucket(boltSeenBucket).Get(pid)
+		if v == nil {
+			return nil
+		}
+		expiresAtNano := int64(binary.BigEndian.Uint64(v))
+		seen = time.Now().UnixNano() < expiresAtNano
+		return nil
+	})
+	return seen
+}
+
+func (s *BoltMessageStore) MarkSeen(pid []byte, ttl time.Duration) {
+	v := make([]byte, 8)
+	binary.BigEndian.PutUint64(v, uint64(time.Now().Add(ttl).UnixNano()))
+
+	_ = s.db.Update(func(tx *bolt.Tx) error {
+		return tx.Bucket(boltSeenBucket).Put(pid, v)
+	})
+}
+
+func (s *BoltMessageStore) SaveOutbound(pid []byte, payload *payloads.Payload, dests []string) {
+	encoded, err := encodePendingOutbound(payload, dests)
+	if err != nil {
+		return
+	}
+
+	_ = s.db.Update(func(tx *bolt.Tx) error {
+		return tx.Bucket(boltPendingBucket).Put(pid, encoded)
+	})
+}
+
+func (s *BoltMessageStore) AckOutbound(pid []byte) {
+	_ = s.db.Update(func(tx *bolt.Tx) error {
+		return tx.Bucket(boltPendingBucket).Delete(pid)
+	})
+}
+
+func (s *BoltMessageStore) PendingOutbound() []PendingOutbound {
+	var pending []PendingOutbound
+
+	_ = s.db.View(func(tx *bolt.Tx) error {
+		return tx.Bucket(boltPendingBucket).ForEach(func(pid, v []byte) error {
+			payload, dests, err := decodePendingOutbound(v)
+			if err != nil {
+				return nil
+			}
+			pid := append([]byte(nil), pid...)
+			pending = append(pending, PendingOutbound{Pid: pid, Payload: payload, Dests: dests})
+			return nil
+		})
+	})
+
+	return pending
+}
+
+// encodePendingOutbound serializes payload as a length-prefixed proto
+// message followed by dests joined with NUL, so a single BoltDB value can
+// carry both.
+func encodePendingOutbound(payload *payloads.Payload, dests []string) ([]byte, error) {
+	payloadBytes, err := proto.Marshal(payload)
+	if err != nil {
+		return nil, err
+	}
+	buf := appendLengthPrefixed(nil, payloadBytes)
+	buf = appendLengthPrefixed(buf, []byte(strings.Join(dests, "\x00")))
+	return buf, nil
+}
+
+func decodePendingOutbound(v []byte) (*payloads.Payload, []string, error) {
+	payloadBytes, rest, err := readLengthPrefixed(v)
+	if err != nil {
+		return nil, nil, err
+	}
+	destsBytes, _, err := readLengthPrefixed(rest)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	payload := &payloads.Payload{}
+	if err := proto.Unmarshal(payloadBytes, payload); err != nil {
+		return nil, nil, err
+	}
+
+	var dests []string
+	if len(destsBytes) > 0 {
+		dests = strings.Split(string(destsBytes), "\x00")
+	}
+
+	return payload, dests, nil
+}
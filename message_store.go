@@ -0,0 +1,207 @@
+package nkn_sdk_go
+
+import (
+	"errors"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/patrickmn/go-cache"
+
+	"github.com/nknorg/nkn-sdk-go/payloads"
+)
+
+// DeliveryMode controls the acknowledgement and retry behavior MultiClient
+// applies to a Send call.
+type DeliveryMode int
+
+const (
+	// AtMostOnce sends the payload once through Send, waiting for the first
+	// reply as Send does but never retrying, matching the original Send
+	// behavior.
+	AtMostOnce DeliveryMode = iota
+	// AtLeastOnce retries the payload with exponential backoff until an ack
+	// is received or the deadline expires, possibly delivering duplicates.
+	AtLeastOnce
+	// ExactlyOnce is sent exactly like AtLeastOnce: SendReliable applies no
+	// extra dedup for it. Every retry reuses the same message id, so the
+	// receiver's inbound dedup suppresses duplicates that arrive within its
+	// MessageStore or cache TTL, as it does for AtLeastOnce.
+	ExactlyOnce
+)
+
+const (
+	outboundRetryBaseDelay = 500 * time.Millisecond
+	outboundRetryMaxDelay  = 30 * time.Second
+	// perAttemptSendTimeout bounds a single SendReliable/replay attempt so
+	// an unresponsive destination cannot wedge the retry loop, or the
+	// replay goroutine, past its deadline.
+	perAttemptSendTimeout = 10 * time.Second
+)
+
+// ErrSendTimeout is returned when no sub-client's response channel fires
+// within the timeout passed to sendPayloadWithTimeout.
+var ErrSendTimeout = errors.New("timed out waiting for a response")
+
+// PendingOutbound describes an outbound payload a MessageStore has not yet
+// seen acknowledged, as returned by MessageStore.PendingOutbound for replay
+// after a restart.
+type PendingOutbound struct {
+	Pid     []byte
+	Payload *payloads.Payload
+	Dests   []string
+}
+
+// MessageStore persists inbound dedup state and outbound delivery state so
+// a MultiClient can survive a restart without re-delivering messages it
+// already saw, and without losing track of messages it has not yet gotten
+// an ack for. Mobile clients, which get killed and resumed frequently, are
+// the primary reason this is pluggable rather than always in-memory.
+type MessageStore interface {
+	// Seen reports whether pid has already been observed as an inbound
+	// message, within whatever TTL it was marked seen with.
+	Seen(pid []byte) bool
+	// MarkSeen records pid as an observed inbound message for ttl.
+	MarkSeen(pid []byte, ttl time.Duration)
+	// SaveOutbound records payload as sent to dests, pending an ack.
+	SaveOutbound(pid []byte, payload *payloads.Payload, dests []string)
+	// AckOutbound records that payload pid has been acknowledged and no
+	// longer needs to be retried or replayed.
+	AckOutbound(pid []byte)
+	// PendingOutbound returns every outbound payload that has not been
+	// acknowledged, for replay on startup or retry.
+	PendingOutbound() []PendingOutbound
+}
+
+// MemoryMessageStore is the default MessageStore, equivalent to the
+// patrickmn/go-cache dedup MultiClient used before MessageStore existed. It
+// does not survive a process restart.
+type MemoryMessageStore struct {
+	seen *cache.Cache
+
+	sync.Mutex
+	pending map[string]PendingOutbound
+}
+
+// NewMemoryMessageStore creates an empty in-memory MessageStore.
+func NewMemoryMessageStore() *MemoryMessageStore {
+	return &MemoryMessageStore{
+		seen:    cache.New(cache.NoExpiration, time.Minute),
+		pending: make(map[string]PendingOutbound),
+	}
+}
+
+func (s *MemoryMessageStore) Seen(pid []byte) bool {
+	_, ok := s.seen.Get(string(pid))
+	return ok
+}
+
+func (s *MemoryMessageStore) MarkSeen(pid []byte, ttl time.Duration) {
+	if ttl <= 0 {
+		s.seen.Delete(string(pid))
+		return
+	}
+	s.seen.Set(string(pid), struct{}{}, ttl)
+}
+
+func (s *MemoryMessageStore) SaveOutbound(pid []byte, payload *payloads.Payload, dests []string) {
+	s.Lock()
+	defer s.Unlock()
+	s.pending[string(pid)] = PendingOutbound{Pid: pid, Payload: payload, Dests: dests}
+}
+
+func (s *MemoryMessageStore) AckOutbound(pid []byte) {
+	s.Lock()
+	defer s.Unlock()
+	delete(s.pending, string(pid))
+}
+
+func (s *MemoryMessageStore) PendingOutbound() []PendingOutbound {
+	s.Lock()
+	defer s.Unlock()
+
+	pending := make([]PendingOutbound, 0, len(s.pending))
+	for _, p := range s.pending {
+		pending = append(pending, p)
+	}
+	return pending
+}
+
+// replayPendingOutbound resends every payload the configured MessageStore
+// still considers unacknowledged. It is called once from NewMultiClient and
+// performs the resends in the background, so a mobile client that was killed
+// mid-delivery picks up where it left off without NewMultiClient blocking
+// on those sends.
+// Each pending message is replayed on its own goroutine, bounded by
+// perAttemptSendTimeout, so one unresponsive destination cannot hold up the
+// replay of every other pending message behind it.
+func (m *MultiClient) replayPendingOutbound() {
+	store := m.config.MessageStore
+	if store == nil {
+		return
+	}
+
+	for _, p := range store.PendingOutbound() {
+		p := p
+		go func() {
+			if _, err := m.sendPayloadWithTimeout(p.Dests, p.Payload, true, perAttemptSendTimeout); err != nil {
+				log.Println("Failed to replay pending outbound message:", err)
+				return
+			}
+			store.AckOutbound(p.Pid)
+		}()
+	}
+}
+
+// SendReliable is Send with an explicit DeliveryMode. AtLeastOnce and
+// ExactlyOnce retry with exponential backoff, starting at
+// outboundRetryBaseDelay and capped at outboundRetryMaxDelay, until an ack
+// is received or deadline elapses. Each attempt is itself bounded by
+// perAttemptSendTimeout (and by whatever remains of deadline, if sooner),
+// so deadline is enforced even against a destination that never acks.
+func (m *MultiClient) SendReliable(dests []string, data []byte, mode DeliveryMode, deadline time.Time) (*Message, error) {
+	if mode == AtMostOnce {
+		return m.Send(dests, data, true)
+	}
+
+	payload, err := m.buildOutboundPayload(dests, data)
+	if err != nil {
+		return nil, err
+	}
+
+	store := m.config.MessageStore
+	if store != nil {
+		store.SaveOutbound(payload.Pid, payload, dests)
+	}
+
+	delay := outboundRetryBaseDelay
+	for {
+		attemptTimeout := perAttemptSendTimeout
+		if !deadline.IsZero() {
+			if remaining := time.Until(deadline); remaining < attemptTimeout {
+				attemptTimeout = remaining
+			}
+		}
+		if attemptTimeout <= 0 {
+			return nil, ErrSendTimeout
+		}
+
+		msg, err := m.sendPayloadWithTimeout(dests, payload, true, attemptTimeout)
+		if err == nil {
+			if store != nil {
+				store.AckOutbound(payload.Pid)
+			}
+			return msg, nil
+		}
+
+		if !deadline.IsZero() && !time.Now().Before(deadline) {
+			return nil, err
+		}
+
+		time.Sleep(delay)
+		delay *= 2
+		if delay > outboundRetryMaxDelay {
+			delay = outboundRetryMaxDelay
+		}
+	}
+}
@@ -0,0 +1,71 @@
+package nkn_sdk_go
+
+import (
+	"testing"
+	"time"
+
+	"github.com/nknorg/nkn-sdk-go/payloads"
+)
+
+func TestMemoryMessageStoreSeenExpiresWithTTL(t *testing.T) {
+	s := NewMemoryMessageStore()
+	pid := []byte("pid-1")
+
+	if s.Seen(pid) {
+		t.Fatal("expected an unmarked pid not to be seen")
+	}
+
+	s.MarkSeen(pid, time.Hour)
+	if !s.Seen(pid) {
+		t.Fatal("expected a marked pid to be seen within its TTL")
+	}
+
+	s.MarkSeen(pid, -time.Second)
+	if s.Seen(pid) {
+		t.Fatal("expected a pid marked with an already-elapsed TTL not to be seen")
+	}
+}
+
+func TestMemoryMessageStorePendingOutboundLifecycle(t *testing.T) {
+	s := NewMemoryMessageStore()
+	pid := []byte("pid-2")
+	payload := &payloads.Payload{Type: payloads.BINARY, Data: []byte("hello")}
+	dests := []string{"dest-1", "dest-2"}
+
+	s.SaveOutbound(pid, payload, dests)
+
+	pending := s.PendingOutbound()
+	if len(pending) != 1 {
+		t.Fatalf("expected 1 pending outbound message, got %d", len(pending))
+	}
+	if string(pending[0].Pid) != string(pid) {
+		t.Fatalf("expected pid %q, got %q", pid, pending[0].Pid)
+	}
+
+	s.AckOutbound(pid)
+	if len(s.PendingOutbound()) != 0 {
+		t.Fatal("expected no pending outbound messages after AckOutbound")
+	}
+}
+
+func TestEncodeDecodePendingOutboundRoundTrip(t *testing.T) {
+	payload := &payloads.Payload{Type: payloads.BINARY, Data: []byte("hello"), Pid: []byte("pid-3")}
+	dests := []string{"dest-1", "dest-2"}
+
+	encoded, err := encodePendingOutbound(payload, dests)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	decodedPayload, decodedDests, err := decodePendingOutbound(encoded)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if string(decodedPayload.Data) != "hello" {
+		t.Fatalf("expected decoded data %q, got %q", "hello", decodedPayload.Data)
+	}
+	if len(decodedDests) != 2 || decodedDests[0] != "dest-1" || decodedDests[1] != "dest-2" {
+		t.Fatalf("expected dests %v, got %v", dests, decodedDests)
+	}
+}
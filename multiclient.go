@@ -38,8 +38,10 @@ type MultiClient struct {
 	onClose       chan struct{}
 
 	sync.RWMutex
-	sessions map[string]*ncp.Session
-	isClosed bool
+	sessions    map[string]*ncp.Session
+	isClosed    bool
+	router      *router
+	paidReceive paidReceiveState
 }
 
 func NewMultiClient(account *vault.Account, baseIdentifier string, numSubClients int, originalClient bool, configs ...ClientConfig) (*MultiClient, error) {
@@ -120,6 +122,12 @@ func NewMultiClient(account *vault.Account, baseIdentifier string, numSubClients
 		onClose:       make(chan struct{}, 0),
 	}
 
+	clientIDs := make([]int, 0, len(clients))
+	for id := range clients {
+		clientIDs = append(clientIDs, id)
+	}
+	m.router = newRouter(config.RoutingPolicy, clientIDs)
+
 	c := cache.New(config.MsgCacheExpiration, config.MsgCacheExpiration)
 	go func() {
 		cases := make([]reflect.SelectCase, numClients)
@@ -149,11 +157,46 @@ func NewMultiClient(account *vault.Account, baseIdentifier string, numSubClients
 						continue
 					}
 				} else {
-					cacheKey := string(msg.Pid)
-					if _, ok := c.Get(cacheKey); ok {
+					if m.config.MessageStore != nil {
+						if m.config.MessageStore.Seen(msg.Pid) {
+							continue
+						}
+						m.config.MessageStore.MarkSeen(msg.Pid, config.MsgCacheExpiration)
+					} else {
+						cacheKey := string(msg.Pid)
+						if _, ok := c.Get(cacheKey); ok {
+							continue
+						}
+						c.Set(cacheKey, struct{}{}, cache.DefaultExpiration)
+					}
+
+					if ad, ok := isPriceAdvertisement(msg.Data); ok {
+						src, _ := removeIdentifier(msg.Src)
+						m.recordPriceAdvertisement(src, ad)
 						continue
 					}
-					c.Set(cacheKey, struct{}{}, cache.DefaultExpiration)
+
+					// Unwrap in the reverse order Send wraps: a claim, when
+					// present, is attached on top of the already-signed
+					// envelope, so it must be stripped first and the
+					// signature verified against what's left underneath.
+					if m.paidReceive.enabled {
+						paid, err := m.checkClaim(msg.Src, msg.Data)
+						if err != nil {
+							m.sendNack(msg.Src)
+							continue
+						}
+						msg.Data = paid
+					}
+
+					if m.config.Signer != nil {
+						verified, err := verifyEnvelope(m.config.Signer, msg.Data)
+						if err != nil {
+							log.Println("Dropping message with invalid signature from", msg.Src, ":", err)
+							continue
+						}
+						msg.Data = verified
+					}
 
 					msg.Src, _ = removeIdentifier(msg.Src)
 					msg.Reply = func(response []byte) {
@@ -163,7 +206,12 @@ func NewMultiClient(account *vault.Account, baseIdentifier string, numSubClients
 						if response == nil {
 							payload, err = newAckPayload(pid)
 						} else {
-							payload, err = newBinaryPayload(response, pid)
+							signed, signErr := signEnvelope(m.config.Signer, response)
+							if signErr != nil {
+								log.Println("Problem signing response to PID " + hex.EncodeToString(pid))
+								return
+							}
+							payload, err = newBinaryPayload(signed, pid)
 						}
 						if err != nil {
 							log.Println("Problem creating response to PID " + hex.EncodeToString(pid))
@@ -178,11 +226,31 @@ func NewMultiClient(account *vault.Account, baseIdentifier string, numSubClients
 		}
 	}()
 
+	m.replayPendingOutbound()
+
 	return m, nil
 }
 
+// buildOutboundPayload wraps data in the same authenticated, and where
+// priced, paid-relay envelope that Send and SendWithClient apply, so every
+// outbound path (including SendReliable) produces a payload the receive
+// dispatch loop can unwrap. Unwrapping on the receive side must strip the
+// claim first and verify the signature on what's left, the reverse of the
+// order applied here.
+func (m *MultiClient) buildOutboundPayload(dests []string, data []byte) (*payloads.Payload, error) {
+	signed, err := signEnvelope(m.config.Signer, data)
+	if err != nil {
+		return nil, err
+	}
+	paid, err := m.attachClaimIfPriced(dests, signed)
+	if err != nil {
+		return nil, err
+	}
+	return newBinaryPayload(paid, nil)
+}
+
 func (m *MultiClient) SendWithClient(clientID int, dests []string, data []byte, encrypted bool, MaxHoldingSeconds ...uint32) (*Message, error) {
-	payload, err := newBinaryPayload(data, nil)
+	payload, err := m.buildOutboundPayload(dests, data)
 	if err != nil {
 		return nil, err
 	}
@@ -204,18 +272,42 @@ func (m *MultiClient) sendWithClient(clientID int, dests []string, payload *payl
 }
 
 func (m *MultiClient) Send(dests []string, data []byte, encrypted bool, MaxHoldingSeconds ...uint32) (*Message, error) {
-	payload, err := newBinaryPayload(data, nil)
+	payload, err := m.buildOutboundPayload(dests, data)
 	if err != nil {
 		return nil, err
 	}
-	responseChannels := make([]chan *Message, len(m.Clients))
+	return m.sendPayload(dests, payload, encrypted, MaxHoldingSeconds...)
+}
+
+// sendPayload is Send for an already-built payload, with no response
+// timeout: it waits indefinitely for a reply, matching the original Send
+// contract. SendReliable uses sendPayloadWithTimeout instead so a deadline
+// can actually be enforced against a destination that never acks.
+func (m *MultiClient) sendPayload(dests []string, payload *payloads.Payload, encrypted bool, MaxHoldingSeconds ...uint32) (*Message, error) {
+	return m.sendPayloadWithTimeout(dests, payload, encrypted, 0, MaxHoldingSeconds...)
+}
+
+// sendPayloadWithTimeout is sendPayload bounded by timeout: if timeout is
+// greater than zero and no sub-client's response channel fires within it,
+// it returns ErrSendTimeout instead of blocking forever.
+func (m *MultiClient) sendPayloadWithTimeout(dests []string, payload *payloads.Payload, encrypted bool, timeout time.Duration, MaxHoldingSeconds ...uint32) (*Message, error) {
+	allClientIDs := make([]int, 0, len(m.Clients))
+	for clientID := range m.Clients {
+		allClientIDs = append(allClientIDs, clientID)
+	}
+	sort.Ints(allClientIDs)
+	clientIDs := m.router.pick(allClientIDs)
+
+	responseChannels := make([]chan *Message, 0, len(clientIDs))
 	pidString := string(payload.Pid)
-	offset := m.offset
-	for clientID, c := range m.Clients {
+	sentAt := time.Now()
+	for _, clientID := range clientIDs {
+		c := m.Clients[clientID]
 		responseChannel := make(chan *Message, 1)
-		responseChannels[clientID+offset] = responseChannel
+		responseChannels = append(responseChannels, responseChannel)
 		c.responseChannels[pidString] = responseChannel
 		if err := m.sendWithClient(clientID, dests, payload, encrypted, MaxHoldingSeconds...); err != nil {
+			m.router.recordResult(clientID, false, 0, "")
 			return nil, err
 		}
 	}
@@ -223,8 +315,15 @@ func (m *MultiClient) Send(dests []string, data []byte, encrypted bool, MaxHoldi
 	for i, responseChannel := range responseChannels {
 		cases[i] = reflect.SelectCase{Dir: reflect.SelectRecv, Chan: reflect.ValueOf(responseChannel)}
 	}
-	if _, value, ok := reflect.Select(cases); ok {
+	if timeout > 0 {
+		cases = append(cases, reflect.SelectCase{Dir: reflect.SelectRecv, Chan: reflect.ValueOf(time.After(timeout))})
+	}
+	if chosen, value, ok := reflect.Select(cases); ok {
+		if timeout > 0 && chosen == len(cases)-1 {
+			return nil, ErrSendTimeout
+		}
 		msg := value.Interface().(*Message)
+		m.router.recordResult(clientIDs[chosen], true, time.Since(sentAt), msg.Src)
 		msg.Src, _ = removeIdentifier(msg.Src)
 		return msg, nil
 	}
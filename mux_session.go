@@ -0,0 +1,417 @@
+package nkn_sdk_go
+
+import (
+	"encoding/binary"
+	"errors"
+	"io"
+	"log"
+	"net"
+	"sync"
+	"time"
+
+	"github.com/nknorg/ncp"
+)
+
+const (
+	// muxStreamIDSize is the size, in bytes, of the stream identifier
+	// prefixed to every frame written on a MuxSession.
+	muxStreamIDSize = 4
+	// muxFrameHeaderSize is muxStreamIDSize plus one byte for the frame
+	// type and four bytes for the payload length.
+	muxFrameHeaderSize = muxStreamIDSize + 1 + 4
+	// muxDefaultWindowSize is the number of unacknowledged bytes a stream
+	// may have in flight before its sender blocks waiting for credit.
+	muxDefaultWindowSize = 256 * 1024
+	// muxMaxFrameSize bounds the payload length a single frame may claim.
+	// It must be at least muxDefaultWindowSize so a stream can always
+	// flush a full window in one frame, and it protects readLoop from a
+	// peer-controlled allocation of unbounded size: any MuxSession
+	// accepted via AcceptMux is reachable by anyone able to message this
+	// NKN address.
+	muxMaxFrameSize = muxDefaultWindowSize
+
+	// muxMaxStreams bounds the number of streams a single MuxSession will
+	// track at once. Without it, a peer could call OpenStream (observed by
+	// readLoop as an unseen stream ID) an unbounded number of times and
+	// force this side to grow m.streams forever; AcceptMux makes that
+	// reachable by anyone able to message this NKN address.
+	muxMaxStreams = 1 << 16
+
+	muxFrameData   = byte(0)
+	muxFrameWindow = byte(1)
+	muxFrameClose  = byte(2)
+)
+
+// ErrMuxSessionClosed is returned by MuxSession operations once the
+// underlying session has been closed.
+var ErrMuxSessionClosed = errors.New("mux session closed")
+
+// errMuxFrameTooLarge closes the session when a peer claims a frame length
+// beyond muxMaxFrameSize, rather than allocating whatever size it asked for.
+var errMuxFrameTooLarge = errors.New("mux frame exceeds muxMaxFrameSize")
+
+// errMuxTooManyStreams closes the session when a peer opens more than
+// muxMaxStreams concurrent streams on it.
+var errMuxTooManyStreams = errors.New("mux session exceeds muxMaxStreams")
+
+// errMuxRecvWindowExceeded closes a stream when a peer sends more data than
+// the receive window it was granted.
+var errMuxRecvWindowExceeded = errors.New("mux stream exceeds recvWindow")
+
+// MuxSession multiplexes many independent net.Conn streams over a single
+// ncp.Session, so a session's setup cost is paid once regardless of how
+// many logical request/response conversations it carries. Each stream is
+// identified by a 4-byte id embedded in every frame and has its own receive
+// buffer and flow-control window.
+type MuxSession struct {
+	session *ncp.Session
+
+	sync.Mutex
+	streams  map[uint32]*muxStream
+	nextID   uint32
+	isServer bool
+	accept   chan *muxStream
+	onClose  chan struct{}
+	closed   bool
+}
+
+// newMuxSession wraps session, reading frames off it in the background and
+// demultiplexing them to the appropriate muxStream.
+func newMuxSession(session *ncp.Session, isServer bool) *MuxSession {
+	m := &MuxSession{
+		session:  session,
+		streams:  make(map[uint32]*muxStream),
+		isServer: isServer,
+		accept:   make(chan *muxStream, 128),
+		onClose:  make(chan struct{}),
+	}
+	if isServer {
+		m.nextID = 1<<31 | 1
+	} else {
+		m.nextID = 1
+	}
+
+	go m.readLoop()
+
+	return m
+}
+
+// DialMux opens a new ncp.Session to remoteAddr, same as Dial, and wraps it
+// in a MuxSession so OpenStream can be used to carry many independent
+// conversations without further session setup.
+func (m *MultiClient) DialMux(remoteAddr string) (*MuxSession, error) {
+	return m.DialMuxWithConfig(remoteAddr, nil)
+}
+
+// DialMuxWithConfig is DialMux with a per-dial SessionConfig override.
+func (m *MultiClient) DialMuxWithConfig(remoteAddr string, config *SessionConfig) (*MuxSession, error) {
+	session, err := m.DialWithConfig(remoteAddr, config)
+	if err != nil {
+		return nil, err
+	}
+	return newMuxSession(session, false), nil
+}
+
+// AcceptMux blocks until an incoming ncp.Session arrives and returns it
+// wrapped in a MuxSession, so the caller can AcceptStream multiple
+// independent conversations on it.
+func (m *MultiClient) AcceptMux() (*MuxSession, error) {
+	session, err := m.AcceptSession()
+	if err != nil {
+		return nil, err
+	}
+	return newMuxSession(session, true), nil
+}
+
+// OpenStream opens a new logical stream over the mux session and returns it
+// as a net.Conn. The peer observes it via AcceptStream.
+func (m *MuxSession) OpenStream() (net.Conn, error) {
+	m.Lock()
+	if m.closed {
+		m.Unlock()
+		return nil, ErrMuxSessionClosed
+	}
+	id := m.nextID
+	m.nextID += 2
+	s := newMuxStream(id, m)
+	m.streams[id] = s
+	m.Unlock()
+
+	return s, nil
+}
+
+// AcceptStream blocks until the peer opens a new stream and returns it as a
+// net.Conn.
+func (m *MuxSession) AcceptStream() (net.Conn, error) {
+	select {
+	case s := <-m.accept:
+		return s, nil
+	case <-m.onClose:
+		return nil, ErrMuxSessionClosed
+	}
+}
+
+// Close closes every open stream and the underlying ncp.Session.
+func (m *MuxSession) Close() error {
+	m.Lock()
+	if m.closed {
+		m.Unlock()
+		return nil
+	}
+	m.closed = true
+	streams := make([]*muxStream, 0, len(m.streams))
+	for _, s := range m.streams {
+		streams = append(streams, s)
+	}
+	m.Unlock()
+
+	for _, s := range streams {
+		s.closeLocal()
+	}
+	close(m.onClose)
+
+	return m.session.Close()
+}
+
+func encodeFrameHeader(streamID uint32, frameType byte, payloadLen uint32) []byte {
+	header := make([]byte, muxFrameHeaderSize)
+	binary.BigEndian.PutUint32(header[:muxStreamIDSize], streamID)
+	header[muxStreamIDSize] = frameType
+	binary.BigEndian.PutUint32(header[muxStreamIDSize+1:], payloadLen)
+	return header
+}
+
+func decodeFrameHeader(header []byte) (streamID uint32, frameType byte, payloadLen uint32) {
+	streamID = binary.BigEndian.Uint32(header[:muxStreamIDSize])
+	frameType = header[muxStreamIDSize]
+	payloadLen = binary.BigEndian.Uint32(header[muxStreamIDSize+1:])
+	return streamID, frameType, payloadLen
+}
+
+func (m *MuxSession) writeFrame(streamID uint32, frameType byte, payload []byte) error {
+	header := encodeFrameHeader(streamID, frameType, uint32(len(payload)))
+	_, err := m.session.Write(append(header, payload...))
+	return err
+}
+
+// readLoop demultiplexes frames off the underlying session and routes them
+// to the matching muxStream, creating one on first sight of an unseen
+// stream ID so the peer's OpenStream is observed via AcceptStream.
+func (m *MuxSession) readLoop() {
+	header := make([]byte, muxFrameHeaderSize)
+	for {
+		if _, err := io.ReadFull(m.session, header); err != nil {
+			m.Close()
+			return
+		}
+
+		streamID, frameType, length := decodeFrameHeader(header)
+
+		if length > muxMaxFrameSize {
+			log.Println(errMuxFrameTooLarge)
+			m.Close()
+			return
+		}
+
+		payload := make([]byte, length)
+		if length > 0 {
+			if _, err := io.ReadFull(m.session, payload); err != nil {
+				m.Close()
+				return
+			}
+		}
+
+		m.Lock()
+		s, ok := m.streams[streamID]
+		if !ok {
+			if len(m.streams) >= muxMaxStreams {
+				m.Unlock()
+				log.Println(errMuxTooManyStreams)
+				m.Close()
+				return
+			}
+			s = newMuxStream(streamID, m)
+			m.streams[streamID] = s
+			m.Unlock()
+			select {
+			case m.accept <- s:
+			default:
+			}
+		} else {
+			m.Unlock()
+		}
+
+		switch frameType {
+		case muxFrameData:
+			if err := s.receive(payload); err != nil {
+				log.Println(err)
+				m.Close()
+				return
+			}
+		case muxFrameWindow:
+			s.addSendWindow(binary.BigEndian.Uint32(payload))
+		case muxFrameClose:
+			s.closeRemote()
+		}
+	}
+}
+
+// muxStream is a single logical conversation within a MuxSession. Its send
+// side is flow-controlled by the peer's advertised window: writes block
+// once the peer's window for this stream is exhausted, resuming when a
+// window-update control frame arrives.
+type muxStream struct {
+	id      uint32
+	session *MuxSession
+
+	sendWindowCond *sync.Cond
+	sendWindow     int64
+
+	recvMu     sync.Mutex
+	recvBuf    []byte
+	recvReady  chan struct{}
+	recvWindow int64
+
+	closeOnce sync.Once
+	closed    chan struct{}
+}
+
+func newMuxStream(id uint32, session *MuxSession) *muxStream {
+	s := &muxStream{
+		id:             id,
+		session:        session,
+		sendWindowCond: sync.NewCond(&sync.Mutex{}),
+		sendWindow:     muxDefaultWindowSize,
+		recvReady:      make(chan struct{}, 1),
+		recvWindow:     muxDefaultWindowSize,
+		closed:         make(chan struct{}),
+	}
+	return s
+}
+
+func (s *muxStream) Read(b []byte) (int, error) {
+	for {
+		s.recvMu.Lock()
+		if len(s.recvBuf) > 0 {
+			n := copy(b, s.recvBuf)
+			s.recvBuf = s.recvBuf[n:]
+			s.recvMu.Unlock()
+			s.grantWindow(n)
+			return n, nil
+		}
+		s.recvMu.Unlock()
+
+		select {
+		case <-s.recvReady:
+		case <-s.closed:
+			s.recvMu.Lock()
+			n := copy(b, s.recvBuf)
+			s.recvBuf = s.recvBuf[n:]
+			s.recvMu.Unlock()
+			if n > 0 {
+				return n, nil
+			}
+			return 0, io.EOF
+		}
+	}
+}
+
+func (s *muxStream) Write(b []byte) (int, error) {
+	written := 0
+	for written < len(b) {
+		s.sendWindowCond.L.Lock()
+		for s.sendWindow <= 0 {
+			select {
+			case <-s.closed:
+				s.sendWindowCond.L.Unlock()
+				return written, ErrMuxSessionClosed
+			default:
+			}
+			s.sendWindowCond.Wait()
+		}
+		n := int64(len(b) - written)
+		if n > s.sendWindow {
+			n = s.sendWindow
+		}
+		s.sendWindow -= n
+		s.sendWindowCond.L.Unlock()
+
+		if err := s.session.writeFrame(s.id, muxFrameData, b[written:written+int(n)]); err != nil {
+			return written, err
+		}
+		written += int(n)
+	}
+	return written, nil
+}
+
+// receive buffers data for the reader, enforcing recvWindow against the
+// peer: Write on the other end is only supposed to send as much as the
+// window it was last granted, so data arriving beyond the window still
+// outstanding means the peer is either buggy or actively ignoring flow
+// control, and the cumulative recvBuf it is feeding us would otherwise grow
+// without bound.
+func (s *muxStream) receive(data []byte) error {
+	s.recvMu.Lock()
+	defer s.recvMu.Unlock()
+
+	if int64(len(data)) > s.recvWindow {
+		return errMuxRecvWindowExceeded
+	}
+	s.recvWindow -= int64(len(data))
+	s.recvBuf = append(s.recvBuf, data...)
+
+	select {
+	case s.recvReady <- struct{}{}:
+	default:
+	}
+	return nil
+}
+
+// grantWindow returns n bytes of receive window back to the peer once the
+// reader has consumed them, so the peer's Write can keep making progress.
+func (s *muxStream) grantWindow(n int) {
+	if n <= 0 {
+		return
+	}
+	s.recvMu.Lock()
+	s.recvWindow += int64(n)
+	s.recvMu.Unlock()
+
+	update := make([]byte, 4)
+	binary.BigEndian.PutUint32(update, uint32(n))
+	_ = s.session.writeFrame(s.id, muxFrameWindow, update)
+}
+
+func (s *muxStream) addSendWindow(n uint32) {
+	s.sendWindowCond.L.Lock()
+	s.sendWindow += int64(n)
+	s.sendWindowCond.L.Unlock()
+	s.sendWindowCond.Broadcast()
+}
+
+func (s *muxStream) closeLocal() {
+	_ = s.session.writeFrame(s.id, muxFrameClose, nil)
+	s.closeRemote()
+}
+
+func (s *muxStream) closeRemote() {
+	s.closeOnce.Do(func() {
+		close(s.closed)
+		s.sendWindowCond.Broadcast()
+	})
+}
+
+func (s *muxStream) Close() error {
+	s.session.Lock()
+	delete(s.session.streams, s.id)
+	s.session.Unlock()
+	s.closeLocal()
+	return nil
+}
+
+func (s *muxStream) LocalAddr() net.Addr  { return s.session.session.LocalAddr() }
+func (s *muxStream) RemoteAddr() net.Addr { return s.session.session.RemoteAddr() }
+
+func (s *muxStream) SetDeadline(t time.Time) error      { return s.session.session.SetDeadline(t) }
+func (s *muxStream) SetReadDeadline(t time.Time) error  { return s.session.session.SetReadDeadline(t) }
+func (s *muxStream) SetWriteDeadline(t time.Time) error { return s.session.session.SetWriteDeadline(t) }
@@ -0,0 +1,41 @@
+package nkn_sdk_go
+
+import "testing"
+
+func TestFrameHeaderRoundTrip(t *testing.T) {
+	header := encodeFrameHeader(42, muxFrameData, 1234)
+	if len(header) != muxFrameHeaderSize {
+		t.Fatalf("expected header of length %d, got %d", muxFrameHeaderSize, len(header))
+	}
+
+	streamID, frameType, length := decodeFrameHeader(header)
+	if streamID != 42 {
+		t.Fatalf("expected stream id 42, got %d", streamID)
+	}
+	if frameType != muxFrameData {
+		t.Fatalf("expected frame type %d, got %d", muxFrameData, frameType)
+	}
+	if length != 1234 {
+		t.Fatalf("expected length 1234, got %d", length)
+	}
+}
+
+func TestFrameHeaderPreservesLengthBeyondMuxMaxFrameSize(t *testing.T) {
+	header := encodeFrameHeader(1, muxFrameData, muxMaxFrameSize+1)
+	_, _, length := decodeFrameHeader(header)
+	if length <= muxMaxFrameSize {
+		t.Fatalf("expected decoded length %d to exceed muxMaxFrameSize %d", length, muxMaxFrameSize)
+	}
+}
+
+func TestMuxStreamReceiveRejectsDataBeyondRecvWindow(t *testing.T) {
+	s := newMuxStream(1, nil)
+	s.recvWindow = 4
+
+	if err := s.receive(make([]byte, 4)); err != nil {
+		t.Fatalf("expected data within the window to be accepted, got: %v", err)
+	}
+	if err := s.receive(make([]byte, 1)); err != errMuxRecvWindowExceeded {
+		t.Fatalf("expected errMuxRecvWindowExceeded once the window is exhausted, got: %v", err)
+	}
+}
@@ -0,0 +1,251 @@
+package nkn_sdk_go
+
+import (
+	"bytes"
+	"errors"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	// priceAdvertisementPrefix tags the well-known control message a
+	// receiver sends so senders learn its price-per-byte and nano-pay
+	// recipient address.
+	priceAdvertisementPrefix = "__price__"
+	// nanoPayClaimPrefix tags the control message attached to a payload
+	// carrying the sender's latest nano-pay claim.
+	nanoPayClaimPrefix = "__claim__"
+	// nanoPayNackPrefix tags the control frame a receiver sends back when
+	// an underpaid message is dropped.
+	nanoPayNackPrefix = "__nack__"
+	// nanoPaySubmitMargin is how long before a nano-pay channel expires
+	// that the goroutine started by SetPaymentSource submits the latest
+	// claim on-chain.
+	nanoPaySubmitMargin = time.Minute
+)
+
+// ErrUnderpaid is returned by checkClaim when a message's nano-pay claim is
+// missing, malformed, does not cover the price of the message it was
+// attached to, or does not increase on the sender's previous claim. The
+// receiver then drops the message and sends the sender a NACK control frame
+// tagged with nanoPayNackPrefix; the error value itself is not sent.
+var ErrUnderpaid = errors.New("message underpaid")
+
+// PriceAdvertisement is the well-known control message a paid-receive
+// MultiClient broadcasts so senders know what to pay and where.
+type PriceAdvertisement struct {
+	PricePerByte float64
+	Address      string
+}
+
+// nanoPayClaim is the per-message claim a sender attaches to cover a paid
+// message's size. Claims must monotonically increase in Amount for a given
+// channel, matching how WalletSDK.NewNanoPay accumulates off-chain.
+type nanoPayClaim struct {
+	Amount string
+}
+
+// EnablePaidReceive makes m advertise price as its per-byte rate and addr as
+// its nano-pay recipient, dropping any inbound message whose attached claim
+// does not cover its size at that rate.
+func (m *MultiClient) EnablePaidReceive(price float64, addr string) {
+	m.paidReceive.Lock()
+	defer m.paidReceive.Unlock()
+
+	m.paidReceive.enabled = true
+	m.paidReceive.pricePerByte = price
+	m.paidReceive.address = addr
+	m.paidReceive.claimed = make(map[string]float64)
+}
+
+// SetPaymentSource attaches np as the nano-pay channel used to fund outbound
+// paid messages. Each Send covering a paid destination attaches an
+// incremental claim against np, submitted on-chain by a background
+// goroutine shortly before the channel expires.
+func (m *MultiClient) SetPaymentSource(np *NanoPay) {
+	m.paidReceive.Lock()
+	m.paidReceive.source = np
+	m.paidReceive.Unlock()
+
+	go m.submitClaimsBeforeExpiry(np)
+}
+
+type paidReceiveState struct {
+	sync.Mutex
+	enabled      bool
+	pricePerByte float64
+	address      string
+	source       *NanoPay
+	// claimed tracks, per sender address, the highest claim amount seen so
+	// far so a replayed or smaller claim can be rejected as non-monotonic.
+	claimed map[string]float64
+	// knownPrices tracks, per destination NKN address, the last
+	// PriceAdvertisement received from it, so Send/SendWithClient know
+	// whether and how much to attach a claim for.
+	knownPrices map[string]PriceAdvertisement
+}
+
+// recordPriceAdvertisement stores ad as src's current price, learned from a
+// received price-advertisement control message.
+func (m *MultiClient) recordPriceAdvertisement(src string, ad PriceAdvertisement) {
+	m.paidReceive.Lock()
+	defer m.paidReceive.Unlock()
+
+	if m.paidReceive.knownPrices == nil {
+		m.paidReceive.knownPrices = make(map[string]PriceAdvertisement)
+	}
+	m.paidReceive.knownPrices[src] = ad
+}
+
+// attachClaimIfPriced attaches an incremental nano-pay claim to data when
+// dests is a single destination this MultiClient has learned a price for
+// and a payment source has been configured via SetPaymentSource. It returns
+// data unmodified for unpriced or multi-destination sends.
+func (m *MultiClient) attachClaimIfPriced(dests []string, data []byte) ([]byte, error) {
+	if len(dests) != 1 {
+		return data, nil
+	}
+
+	m.paidReceive.Lock()
+	ad, priced := m.paidReceive.knownPrices[dests[0]]
+	source := m.paidReceive.source
+	m.paidReceive.Unlock()
+
+	if !priced {
+		return data, nil
+	}
+
+	return attachClaim(data, source, ad.PricePerByte)
+}
+
+// checkClaim verifies that data carries a claim covering its own size at the
+// advertised rate and that the claim strictly increases for src, returning
+// the message payload with the claim control frame stripped.
+func (m *MultiClient) checkClaim(src string, data []byte) ([]byte, error) {
+	m.paidReceive.Lock()
+	defer m.paidReceive.Unlock()
+
+	if !m.paidReceive.enabled {
+		return data, nil
+	}
+
+	claim, rest, ok := parseNanoPayClaim(data)
+	if !ok {
+		return nil, ErrUnderpaid
+	}
+
+	amount, err := strconv.ParseFloat(claim.Amount, 64)
+	if err != nil {
+		return nil, ErrUnderpaid
+	}
+
+	required := m.paidReceive.pricePerByte * float64(len(rest))
+	if amount < required {
+		return nil, ErrUnderpaid
+	}
+
+	if amount <= m.paidReceive.claimed[src] {
+		return nil, ErrUnderpaid
+	}
+	m.paidReceive.claimed[src] = amount
+
+	return rest, nil
+}
+
+func parseNanoPayClaim(data []byte) (nanoPayClaim, []byte, bool) {
+	if !bytes.HasPrefix(data, []byte(nanoPayClaimPrefix)) {
+		return nanoPayClaim{}, nil, false
+	}
+	data = data[len(nanoPayClaimPrefix):]
+	idx := indexByte(data, 0)
+	if idx < 0 {
+		return nanoPayClaim{}, nil, false
+	}
+	return nanoPayClaim{Amount: string(data[:idx])}, data[idx+1:], true
+}
+
+// attachClaim wraps data with an incremental nano-pay claim covering its
+// size at price, for sending to a paid-receive destination.
+func attachClaim(data []byte, np *NanoPay, price float64) ([]byte, error) {
+	if np == nil {
+		return nil, errors.New("no payment source configured, call SetPaymentSource first")
+	}
+
+	amount := price * float64(len(data))
+	if _, err := np.IncrementAmount(amount); err != nil {
+		return nil, err
+	}
+
+	claim := append([]byte(nanoPayClaimPrefix+strconv.FormatFloat(np.Amount(), 'f', -1, 64)+"\x00"), data...)
+	return claim, nil
+}
+
+// sendNack notifies src that its message was dropped for being underpaid.
+func (m *MultiClient) sendNack(src string) {
+	payload, err := newBinaryPayload([]byte(nanoPayNackPrefix), nil)
+	if err != nil {
+		return
+	}
+	_ = m.send([]string{src}, payload, true)
+}
+
+func isPriceAdvertisement(data []byte) (PriceAdvertisement, bool) {
+	if !bytes.HasPrefix(data, []byte(priceAdvertisementPrefix)) {
+		return PriceAdvertisement{}, false
+	}
+	body := string(data[len(priceAdvertisementPrefix):])
+	parts := strings.SplitN(body, "\x00", 2)
+	if len(parts) != 2 {
+		return PriceAdvertisement{}, false
+	}
+	price, err := strconv.ParseFloat(parts[0], 64)
+	if err != nil {
+		return PriceAdvertisement{}, false
+	}
+	return PriceAdvertisement{PricePerByte: price, Address: parts[1]}, true
+}
+
+// AdvertisePrice broadcasts this MultiClient's current price-per-byte and
+// nano-pay recipient address to dests, so they know what to pay before
+// sending paid messages.
+func (m *MultiClient) AdvertisePrice(dests []string) error {
+	m.paidReceive.Lock()
+	price, addr := m.paidReceive.pricePerByte, m.paidReceive.address
+	m.paidReceive.Unlock()
+
+	body := priceAdvertisementPrefix + strconv.FormatFloat(price, 'f', -1, 64) + "\x00" + addr
+	payload, err := newBinaryPayload([]byte(body), nil)
+	if err != nil {
+		return err
+	}
+	return m.send(dests, payload, true)
+}
+
+// submitClaimsBeforeExpiry submits np's latest accumulated claim on-chain
+// shortly before the channel it funds expires, keeping the paid relay
+// solvent without requiring the caller to track expiry themselves.
+func (m *MultiClient) submitClaimsBeforeExpiry(np *NanoPay) {
+	for {
+		expiresIn := np.ExpiresIn()
+		if expiresIn <= 0 {
+			return
+		}
+
+		wait := expiresIn - nanoPaySubmitMargin
+		if wait < 0 {
+			wait = 0
+		}
+
+		select {
+		case <-time.After(wait):
+		case <-m.onClose:
+			return
+		}
+
+		if _, err := np.Submit(); err != nil {
+			return
+		}
+	}
+}
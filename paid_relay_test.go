@@ -0,0 +1,52 @@
+package nkn_sdk_go
+
+import "testing"
+
+func TestIsPriceAdvertisementRoundTrip(t *testing.T) {
+	body := priceAdvertisementPrefix + "0.5\x00NKNaddr123"
+	ad, ok := isPriceAdvertisement([]byte(body))
+	if !ok {
+		t.Fatal("expected a valid price advertisement to parse")
+	}
+	if ad.PricePerByte != 0.5 {
+		t.Fatalf("expected price 0.5, got %v", ad.PricePerByte)
+	}
+	if ad.Address != "NKNaddr123" {
+		t.Fatalf("expected address NKNaddr123, got %q", ad.Address)
+	}
+}
+
+func TestIsPriceAdvertisementRejectsUnrelatedData(t *testing.T) {
+	if _, ok := isPriceAdvertisement([]byte("hello world")); ok {
+		t.Fatal("expected unrelated data not to parse as a price advertisement")
+	}
+}
+
+func TestIsPriceAdvertisementRejectsMalformedBody(t *testing.T) {
+	if _, ok := isPriceAdvertisement([]byte(priceAdvertisementPrefix + "not-a-number\x00addr")); ok {
+		t.Fatal("expected a non-numeric price to fail to parse")
+	}
+	if _, ok := isPriceAdvertisement([]byte(priceAdvertisementPrefix + "0.5")); ok {
+		t.Fatal("expected a missing address separator to fail to parse")
+	}
+}
+
+func TestParseNanoPayClaimRoundTrip(t *testing.T) {
+	body := nanoPayClaimPrefix + "1.25\x00" + "payload bytes"
+	claim, rest, ok := parseNanoPayClaim([]byte(body))
+	if !ok {
+		t.Fatal("expected a valid claim to parse")
+	}
+	if claim.Amount != "1.25" {
+		t.Fatalf("expected amount 1.25, got %q", claim.Amount)
+	}
+	if string(rest) != "payload bytes" {
+		t.Fatalf("expected rest %q, got %q", "payload bytes", rest)
+	}
+}
+
+func TestParseNanoPayClaimRejectsUnrelatedData(t *testing.T) {
+	if _, _, ok := parseNanoPayClaim([]byte("hello world")); ok {
+		t.Fatal("expected unrelated data not to parse as a claim")
+	}
+}
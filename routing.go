@@ -0,0 +1,226 @@
+package nkn_sdk_go
+
+import (
+	"math/rand"
+	"sync"
+	"time"
+)
+
+// RoutingStrategy selects which of a MultiClient's sub-clients should
+// actually transmit a given Send call.
+type RoutingStrategy int
+
+const (
+	// FanOutAll sends through every sub-client, taking the first reply. It
+	// is the zero value so a zero-value RoutingPolicy (in particular every
+	// MultiClient created without an explicit RoutingPolicy) keeps the
+	// original fan-out-to-all-with-failover behavior.
+	FanOutAll RoutingStrategy = iota
+	// RoundRobin sends through a single sub-client, cycling through all of
+	// them in order across calls.
+	RoundRobin
+	// LowestLatency sends through the sub-client with the lowest observed
+	// RTT.
+	LowestLatency
+	// WeightedRandom sends through a single sub-client chosen at random,
+	// weighted by delivery success rate.
+	WeightedRandom
+	// Redundant sends through the RedundantCount best sub-clients, trading
+	// bandwidth for a lower chance of total delivery failure.
+	Redundant
+)
+
+// RoutingPolicy configures how MultiClient.Send picks sub-clients to
+// transmit through. The zero value uses FanOutAll, matching the original
+// fan-out-to-all behavior.
+type RoutingPolicy struct {
+	Strategy RoutingStrategy
+	// RedundantCount is the number of sub-clients used when Strategy is
+	// Redundant. It is ignored for other strategies.
+	RedundantCount int
+}
+
+// SubClientStats holds the health metrics tracked for a single sub-client so
+// applications can build dashboards or implement their own routing.
+type SubClientStats struct {
+	ClientID        int
+	RTT             time.Duration
+	SuccessRate     float64
+	LastSeenNode    string
+	SentCount       uint64
+	SucceededCount  uint64
+}
+
+type subClientHealth struct {
+	rtt            time.Duration
+	sentCount      uint64
+	succeededCount uint64
+	lastSeenNode   string
+}
+
+// router tracks per-sub-client health and applies a RoutingPolicy to pick
+// which sub-clients MultiClient.Send should use.
+type router struct {
+	sync.Mutex
+	policy      RoutingPolicy
+	health      map[int]*subClientHealth
+	roundRobin  int
+}
+
+func newRouter(policy RoutingPolicy, clientIDs []int) *router {
+	health := make(map[int]*subClientHealth, len(clientIDs))
+	for _, id := range clientIDs {
+		health[id] = &subClientHealth{}
+	}
+	return &router{policy: policy, health: health}
+}
+
+// recordResult updates the health of clientID after an attempted send. rtt
+// is the time to first response, or 0 if the send failed outright.
+func (r *router) recordResult(clientID int, success bool, rtt time.Duration, seenNode string) {
+	r.Lock()
+	defer r.Unlock()
+
+	h, ok := r.health[clientID]
+	if !ok {
+		h = &subClientHealth{}
+		r.health[clientID] = h
+	}
+	h.sentCount++
+	if success {
+		h.succeededCount++
+		h.rtt = rtt
+	}
+	if seenNode != "" {
+		h.lastSeenNode = seenNode
+	}
+}
+
+// pick returns the sub-client IDs, out of candidates, that should transmit
+// for the next Send call under the configured strategy.
+func (r *router) pick(candidates []int) []int {
+	r.Lock()
+	defer r.Unlock()
+
+	if len(candidates) == 0 {
+		return candidates
+	}
+
+	switch r.policy.Strategy {
+	case FanOutAll:
+		return candidates
+	case RoundRobin:
+		id := candidates[r.roundRobin%len(candidates)]
+		r.roundRobin++
+		return []int{id}
+	case LowestLatency:
+		return []int{r.bestByLatency(candidates)}
+	case WeightedRandom:
+		return []int{r.weightedRandomPick(candidates)}
+	case Redundant:
+		n := r.policy.RedundantCount
+		if n <= 0 || n > len(candidates) {
+			n = len(candidates)
+		}
+		return r.bestNByLatency(candidates, n)
+	default:
+		return candidates
+	}
+}
+
+func (r *router) bestByLatency(candidates []int) int {
+	best := candidates[0]
+	bestRTT := r.health[best].rtt
+	for _, id := range candidates[1:] {
+		h := r.health[id]
+		if h.rtt > 0 && (bestRTT == 0 || h.rtt < bestRTT) {
+			best = id
+			bestRTT = h.rtt
+		}
+	}
+	return best
+}
+
+func (r *router) bestNByLatency(candidates []int, n int) []int {
+	ranked := make([]int, len(candidates))
+	copy(ranked, candidates)
+	sortByLatency(ranked, r.health)
+	return ranked[:n]
+}
+
+func sortByLatency(ids []int, health map[int]*subClientHealth) {
+	for i := 1; i < len(ids); i++ {
+		for j := i; j > 0; j-- {
+			a, b := health[ids[j-1]], health[ids[j]]
+			if rttOrMax(b.rtt) < rttOrMax(a.rtt) {
+				ids[j-1], ids[j] = ids[j], ids[j-1]
+			} else {
+				break
+			}
+		}
+	}
+}
+
+func rttOrMax(rtt time.Duration) time.Duration {
+	if rtt <= 0 {
+		return time.Duration(1<<63 - 1)
+	}
+	return rtt
+}
+
+func (r *router) weightedRandomPick(candidates []int) int {
+	weights := make([]float64, len(candidates))
+	var total float64
+	for i, id := range candidates {
+		h := r.health[id]
+		w := 1.0
+		if h.sentCount > 0 {
+			w = float64(h.succeededCount) / float64(h.sentCount)
+			if w <= 0 {
+				w = 0.01
+			}
+		}
+		weights[i] = w
+		total += w
+	}
+
+	target := rand.Float64() * total
+	for i, id := range candidates {
+		target -= weights[i]
+		if target <= 0 {
+			return id
+		}
+	}
+	return candidates[len(candidates)-1]
+}
+
+func (r *router) stats() []SubClientStats {
+	r.Lock()
+	defer r.Unlock()
+
+	stats := make([]SubClientStats, 0, len(r.health))
+	for id, h := range r.health {
+		successRate := 1.0
+		if h.sentCount > 0 {
+			successRate = float64(h.succeededCount) / float64(h.sentCount)
+		}
+		stats = append(stats, SubClientStats{
+			ClientID:       id,
+			RTT:            h.rtt,
+			SuccessRate:    successRate,
+			LastSeenNode:   h.lastSeenNode,
+			SentCount:      h.sentCount,
+			SucceededCount: h.succeededCount,
+		})
+	}
+	return stats
+}
+
+// Stats returns per-sub-client health metrics tracked by the MultiClient's
+// RoutingPolicy, regardless of which strategy is configured.
+func (m *MultiClient) Stats() []SubClientStats {
+	if m.router == nil {
+		return nil
+	}
+	return m.router.stats()
+}
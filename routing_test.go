@@ -0,0 +1,64 @@
+package nkn_sdk_go
+
+import (
+	"testing"
+	"time"
+)
+
+func TestRouterZeroValuePolicyFansOutToAll(t *testing.T) {
+	r := newRouter(RoutingPolicy{}, []int{0, 1, 2})
+	picked := r.pick([]int{0, 1, 2})
+	if len(picked) != 3 {
+		t.Fatalf("zero-value RoutingPolicy should fan out to every candidate, got %v", picked)
+	}
+}
+
+func TestRouterRoundRobinCyclesThroughCandidates(t *testing.T) {
+	r := newRouter(RoutingPolicy{Strategy: RoundRobin}, []int{0, 1, 2})
+	for i := 0; i < 6; i++ {
+		picked := r.pick([]int{0, 1, 2})
+		if len(picked) != 1 {
+			t.Fatalf("RoundRobin should pick exactly one candidate, got %v", picked)
+		}
+		if picked[0] != i%3 {
+			t.Fatalf("expected candidate %d, got %d", i%3, picked[0])
+		}
+	}
+}
+
+func TestRouterBestByLatencyPrefersLowerRTT(t *testing.T) {
+	r := newRouter(RoutingPolicy{Strategy: LowestLatency}, []int{0, 1})
+	r.recordResult(0, true, 100*time.Millisecond, "")
+	r.recordResult(1, true, 10*time.Millisecond, "")
+
+	picked := r.pick([]int{0, 1})
+	if len(picked) != 1 || picked[0] != 1 {
+		t.Fatalf("expected the lower-RTT candidate 1, got %v", picked)
+	}
+}
+
+func TestRouterWeightedRandomPicksOnlySuccessfulCandidateWhenOthersAlwaysFail(t *testing.T) {
+	r := newRouter(RoutingPolicy{Strategy: WeightedRandom}, []int{0, 1})
+	for i := 0; i < 20; i++ {
+		r.recordResult(0, false, 0, "")
+		r.recordResult(1, true, time.Millisecond, "")
+	}
+
+	for i := 0; i < 20; i++ {
+		picked := r.pick([]int{0, 1})
+		if len(picked) != 1 {
+			t.Fatalf("WeightedRandom should pick exactly one candidate, got %v", picked)
+		}
+		if picked[0] != 1 {
+			t.Fatalf("expected the consistently successful candidate 1, got %d", picked[0])
+		}
+	}
+}
+
+func TestRouterRedundantReturnsRequestedCount(t *testing.T) {
+	r := newRouter(RoutingPolicy{Strategy: Redundant, RedundantCount: 2}, []int{0, 1, 2})
+	picked := r.pick([]int{0, 1, 2})
+	if len(picked) != 2 {
+		t.Fatalf("expected 2 candidates from Redundant(2), got %v", picked)
+	}
+}
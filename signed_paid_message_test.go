@@ -0,0 +1,35 @@
+package nkn_sdk_go
+
+import "testing"
+
+// TestSignedAndClaimedMessageUnwrapsInReverseWrapOrder exercises the wire
+// format Send produces when both a Signer and paid relay are configured: a
+// claim wrapped around an already-signed envelope. The receive path must
+// strip the claim first and verify the signature on what's left underneath,
+// mirroring attachClaim(signEnvelope(data)).
+func TestSignedAndClaimedMessageUnwrapsInReverseWrapOrder(t *testing.T) {
+	signer := NewHMACSigner([]byte("group-1"), []byte("shared-key"))
+
+	envelope, err := signEnvelope(signer, []byte("hello"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	claimed := append([]byte(nanoPayClaimPrefix+"1.25\x00"), envelope...)
+
+	claim, rest, ok := parseNanoPayClaim(claimed)
+	if !ok {
+		t.Fatal("expected the claim wrapper to parse")
+	}
+	if claim.Amount != "1.25" {
+		t.Fatalf("expected amount 1.25, got %q", claim.Amount)
+	}
+
+	data, err := verifyEnvelope(signer, rest)
+	if err != nil {
+		t.Fatalf("expected the signed envelope under the claim to verify, got: %v", err)
+	}
+	if string(data) != "hello" {
+		t.Fatalf("expected %q, got %q", "hello", data)
+	}
+}
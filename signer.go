@@ -0,0 +1,199 @@
+package nkn_sdk_go
+
+import (
+	"bytes"
+	"crypto/ed25519"
+	"crypto/hmac"
+	"crypto/sha256"
+	"errors"
+)
+
+// ErrSignatureMismatch is returned by Signer.Verify when the signature does
+// not match the envelope it was attached to.
+var ErrSignatureMismatch = errors.New("signature mismatch")
+
+// Signer authenticates outbound payloads and verifies inbound ones
+// independently of the NaCl box encryption already applied to Message data.
+// Implementations identify themselves with a SignerID so a verifier can pick
+// the right key material (group key, third-party ed25519 key, etc.) without
+// any prior negotiation.
+type Signer interface {
+	// SignerID is included in the envelope so Verify can select the right
+	// key material on the receiving end.
+	SignerID() []byte
+	// Sign returns the signature over payload.
+	Sign(payload []byte) ([]byte, error)
+	// Verify checks sig against payload for the given signerID, returning
+	// ErrSignatureMismatch (or a wrapped error) if it does not match.
+	Verify(payload, signerID, sig []byte) error
+}
+
+// ClearTextSigner is a no-op Signer for applications that want the envelope
+// format without authentication, e.g. during development.
+type ClearTextSigner struct{}
+
+func (ClearTextSigner) SignerID() []byte { return nil }
+
+func (ClearTextSigner) Sign([]byte) ([]byte, error) { return nil, nil }
+
+func (ClearTextSigner) Verify([]byte, []byte, []byte) error { return nil }
+
+// HMACSigner signs payloads with a shared group key, e.g. for group chat
+// where all members hold the same symmetric key.
+type HMACSigner struct {
+	id  []byte
+	key []byte
+}
+
+// NewHMACSigner creates a Signer that authenticates with an HMAC-SHA256 over
+// the payload using key, identifying itself with id.
+func NewHMACSigner(id, key []byte) *HMACSigner {
+	return &HMACSigner{id: id, key: key}
+}
+
+func (s *HMACSigner) SignerID() []byte { return s.id }
+
+func (s *HMACSigner) Sign(payload []byte) ([]byte, error) {
+	mac := hmac.New(sha256.New, s.key)
+	mac.Write(payload)
+	return mac.Sum(nil), nil
+}
+
+func (s *HMACSigner) Verify(payload, signerID, sig []byte) error {
+	if !bytes.Equal(signerID, s.id) {
+		return ErrSignatureMismatch
+	}
+	mac := hmac.New(sha256.New, s.key)
+	mac.Write(payload)
+	if !hmac.Equal(mac.Sum(nil), sig) {
+		return ErrSignatureMismatch
+	}
+	return nil
+}
+
+// Ed25519Signer authenticates with a third-party ed25519 identity key that
+// is independent of the wallet account key used for box encryption.
+type Ed25519Signer struct {
+	id         []byte
+	privateKey ed25519.PrivateKey
+	publicKey  ed25519.PublicKey
+}
+
+// NewEd25519Signer creates a Signer that signs with privateKey and verifies
+// against publicKey, identifying itself with id.
+func NewEd25519Signer(id []byte, privateKey ed25519.PrivateKey, publicKey ed25519.PublicKey) *Ed25519Signer {
+	return &Ed25519Signer{id: id, privateKey: privateKey, publicKey: publicKey}
+}
+
+func (s *Ed25519Signer) SignerID() []byte { return s.id }
+
+func (s *Ed25519Signer) Sign(payload []byte) ([]byte, error) {
+	if len(s.privateKey) == 0 {
+		return nil, errors.New("Ed25519Signer has no private key to sign with")
+	}
+	return ed25519.Sign(s.privateKey, payload), nil
+}
+
+func (s *Ed25519Signer) Verify(payload, signerID, sig []byte) error {
+	if !bytes.Equal(signerID, s.id) {
+		return ErrSignatureMismatch
+	}
+	if !ed25519.Verify(s.publicKey, payload, sig) {
+		return ErrSignatureMismatch
+	}
+	return nil
+}
+
+// SignerConfig configures the pluggable authentication layer applied to
+// payloads sent and received through a Client or MultiClient. It is
+// independent of the NaCl box encryption controlled by the `encrypted`
+// argument to Send, so a Signer can be layered on top of either plaintext or
+// encrypted payloads.
+type SignerConfig struct {
+	// Signer authenticates outbound payloads and verifies inbound ones. A
+	// nil Signer disables the envelope entirely, preserving the previous
+	// wire format.
+	Signer Signer
+}
+
+// signEnvelope wraps data in an authenticated envelope of the form
+// payload bytes || signer-id || signature, each length-prefixed so
+// verifyEnvelope can split them back apart. If signer is nil, data is returned unmodified.
+func signEnvelope(signer Signer, data []byte) ([]byte, error) {
+	if signer == nil {
+		return data, nil
+	}
+
+	sig, err := signer.Sign(data)
+	if err != nil {
+		return nil, err
+	}
+
+	return encodeEnvelope(data, signer.SignerID(), sig), nil
+}
+
+// verifyEnvelope splits an authenticated envelope produced by signEnvelope
+// and verifies it with signer, returning the original payload bytes. If
+// signer is nil, data is returned unmodified and unverified.
+func verifyEnvelope(signer Signer, data []byte) ([]byte, error) {
+	if signer == nil {
+		return data, nil
+	}
+
+	payload, signerID, sig, err := decodeEnvelope(data)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := signer.Verify(payload, signerID, sig); err != nil {
+		return nil, err
+	}
+
+	return payload, nil
+}
+
+func encodeEnvelope(payload, signerID, sig []byte) []byte {
+	buf := make([]byte, 0, 4+len(payload)+4+len(signerID)+4+len(sig))
+	buf = appendLengthPrefixed(buf, payload)
+	buf = appendLengthPrefixed(buf, signerID)
+	buf = appendLengthPrefixed(buf, sig)
+	return buf
+}
+
+func decodeEnvelope(data []byte) (payload, signerID, sig []byte, err error) {
+	payload, data, err = readLengthPrefixed(data)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	signerID, data, err = readLengthPrefixed(data)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	sig, _, err = readLengthPrefixed(data)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	return payload, signerID, sig, nil
+}
+
+func appendLengthPrefixed(buf, b []byte) []byte {
+	n := len(b)
+	buf = append(buf, byte(n>>24), byte(n>>16), byte(n>>8), byte(n))
+	return append(buf, b...)
+}
+
+func readLengthPrefixed(data []byte) (b, rest []byte, err error) {
+	if len(data) < 4 {
+		return nil, nil, errors.New("malformed signed envelope")
+	}
+	// Parsed as uint32, not int: on a 32-bit build (gomobile's target)
+	// a length with its top bit set would otherwise overflow to a
+	// negative int and slip past the len(data) < n bounds check below,
+	// causing a remote-triggerable panic on data[:n].
+	n := uint32(data[0])<<24 | uint32(data[1])<<16 | uint32(data[2])<<8 | uint32(data[3])
+	data = data[4:]
+	if uint32(len(data)) < n {
+		return nil, nil, errors.New("malformed signed envelope")
+	}
+	return data[:n], data[n:], nil
+}
@@ -0,0 +1,114 @@
+package nkn_sdk_go
+
+import (
+	"crypto/ed25519"
+	"testing"
+)
+
+func TestClearTextSignerRoundTrip(t *testing.T) {
+	envelope, err := signEnvelope(ClearTextSigner{}, []byte("hello"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	out, err := verifyEnvelope(ClearTextSigner{}, envelope)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(out) != "hello" {
+		t.Fatalf("expected %q, got %q", "hello", out)
+	}
+}
+
+func TestHMACSignerRoundTrip(t *testing.T) {
+	signer := NewHMACSigner([]byte("group-1"), []byte("shared-key"))
+
+	envelope, err := signEnvelope(signer, []byte("hello"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	out, err := verifyEnvelope(signer, envelope)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(out) != "hello" {
+		t.Fatalf("expected %q, got %q", "hello", out)
+	}
+}
+
+func TestHMACSignerRejectsTamperedPayload(t *testing.T) {
+	signer := NewHMACSigner([]byte("group-1"), []byte("shared-key"))
+
+	envelope, err := signEnvelope(signer, []byte("hello"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	envelope[5] ^= 0xff
+
+	if _, err := verifyEnvelope(signer, envelope); err == nil {
+		t.Fatal("expected tampered envelope to fail verification")
+	}
+}
+
+func TestHMACSignerRejectsWrongKey(t *testing.T) {
+	sender := NewHMACSigner([]byte("group-1"), []byte("shared-key"))
+	receiver := NewHMACSigner([]byte("group-1"), []byte("other-key"))
+
+	envelope, err := signEnvelope(sender, []byte("hello"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := verifyEnvelope(receiver, envelope); err == nil {
+		t.Fatal("expected verification with the wrong key to fail")
+	}
+}
+
+func TestEd25519SignerRoundTrip(t *testing.T) {
+	public, private, err := ed25519.GenerateKey(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	signer := NewEd25519Signer([]byte("identity-1"), private, public)
+
+	envelope, err := signEnvelope(signer, []byte("hello"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	out, err := verifyEnvelope(signer, envelope)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(out) != "hello" {
+		t.Fatalf("expected %q, got %q", "hello", out)
+	}
+}
+
+func TestReadLengthPrefixedRejectsOverflowingLength(t *testing.T) {
+	// A length prefix with the top bit set must not parse as a negative
+	// int and slip past readLengthPrefixed's bounds check.
+	data := []byte{0x80, 0x00, 0x00, 0x00, 1, 2, 3}
+
+	if _, _, err := readLengthPrefixed(data); err == nil {
+		t.Fatal("expected an oversized length prefix to be rejected")
+	}
+}
+
+func TestNilSignerPassesDataThroughUnmodified(t *testing.T) {
+	envelope, err := signEnvelope(nil, []byte("hello"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(envelope) != "hello" {
+		t.Fatalf("nil signer should not modify data, got %q", envelope)
+	}
+
+	out, err := verifyEnvelope(nil, envelope)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(out) != "hello" {
+		t.Fatalf("expected %q, got %q", "hello", out)
+	}
+}
@@ -0,0 +1,260 @@
+package nkn_sdk_go
+
+import (
+	"errors"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	// topicMessagePrefix tags outbound Data so dispatchTopicMessages can
+	// pick pub/sub traffic out of the underlying MultiClient.OnMessage
+	// stream; it consumes that whole stream and drops untagged messages.
+	topicMessagePrefix = "__topic__"
+	// subscriptionRefreshMargin is how many blocks before a subscription's
+	// lease expires that TopicClient re-subscribes.
+	subscriptionRefreshMargin = 5
+	// nknBlockGenerationInterval is NKN mainnet's target block time, used
+	// to convert a subscription's duration (in blocks) into an
+	// approximate wall-clock expiry.
+	nknBlockGenerationInterval = 20 * time.Second
+	// subscriptionRefreshCheckInterval is how often refreshSubscriptions
+	// checks joined topics against their approximate expiry.
+	subscriptionRefreshCheckInterval = time.Minute
+	// defaultSubscribeBucket is bucket 0, for callers of Join that do not
+	// need bucket-based load spreading. Join always uses the bucket it is
+	// passed.
+	defaultSubscribeBucket = 0
+	// allSubscribersLimit asks GetSubscribers for the full subscriber list
+	// rather than a paginated slice.
+	allSubscribersLimit = 0
+)
+
+// TopicMessage is delivered on TopicClient.OnTopicMessage for every message
+// published to a topic the client is listening on.
+type TopicMessage struct {
+	Topic   string
+	Message *Message
+}
+
+// TopicClient turns the raw on-chain subscribe/name primitives exposed by
+// WalletSDK into a pub/sub API: Join/Leave manage on-chain subscriptions,
+// and Publish resolves current subscribers and multicasts to them through
+// the underlying MultiClient.
+type TopicClient struct {
+	*MultiClient
+	wallet *WalletSDK
+
+	sync.Mutex
+	joined map[string]*topicSubscription
+	closed bool
+
+	OnTopicMessage chan *TopicMessage
+	onClose        chan struct{}
+}
+
+type topicSubscription struct {
+	identifier string
+	bucket     uint32
+	duration   uint32
+	meta       string
+	joinedAt   time.Time
+}
+
+// NewTopicClient creates a TopicClient that publishes and listens through
+// multiClient, and manages subscriptions through wallet.
+func NewTopicClient(multiClient *MultiClient, wallet *WalletSDK) *TopicClient {
+	t := &TopicClient{
+		MultiClient:    multiClient,
+		wallet:         wallet,
+		joined:         make(map[string]*topicSubscription),
+		OnTopicMessage: make(chan *TopicMessage, multiClient.config.MsgChanLen),
+		onClose:        make(chan struct{}),
+	}
+
+	go t.dispatchTopicMessages()
+	go t.refreshSubscriptions()
+
+	return t
+}
+
+// Join subscribes the wallet to topic under identifier for duration blocks,
+// in bucket. Re-subscription happens automatically before the lease
+// expires for as long as the TopicClient stays joined.
+func (t *TopicClient) Join(topic, identifier string, bucket, duration uint32, meta string) error {
+	_, err := t.wallet.Subscribe(identifier, topic, bucket, duration, meta)
+	if err != nil {
+		return err
+	}
+
+	t.Lock()
+	t.joined[topic] = &topicSubscription{
+		identifier: identifier,
+		bucket:     bucket,
+		duration:   duration,
+		meta:       meta,
+		joinedAt:   time.Now(),
+	}
+	t.Unlock()
+
+	return nil
+}
+
+// Leave unsubscribes the wallet from topic and stops automatic
+// re-subscription.
+func (t *TopicClient) Leave(topic string) error {
+	t.Lock()
+	sub, ok := t.joined[topic]
+	if !ok {
+		t.Unlock()
+		return errors.New("not joined to topic " + topic)
+	}
+	delete(t.joined, topic)
+	t.Unlock()
+
+	_, err := t.wallet.Unsubscribe(sub.identifier, topic)
+	return err
+}
+
+// Publish resolves the current on-chain subscribers of topic and multicasts
+// data to all of them through the underlying MultiClient.
+func (t *TopicClient) Publish(topic string, data []byte) error {
+	subscribers, err := t.wallet.GetSubscribers(topic, 0, allSubscribersLimit, false, true)
+	if err != nil {
+		return err
+	}
+
+	dests := dedupAddresses(subscribers)
+	if len(dests) == 0 {
+		return errors.New("topic " + topic + " has no subscribers")
+	}
+
+	body := append([]byte(topicMessagePrefix+topic+"\x00"), data...)
+	payload, err := newBinaryPayload(body, nil)
+	if err != nil {
+		return err
+	}
+	return t.send(dests, payload, true)
+}
+
+func dedupAddresses(addrs []string) []string {
+	seen := make(map[string]struct{}, len(addrs))
+	deduped := make([]string, 0, len(addrs))
+	for _, addr := range addrs {
+		if _, ok := seen[addr]; ok {
+			continue
+		}
+		seen[addr] = struct{}{}
+		deduped = append(deduped, addr)
+	}
+	return deduped
+}
+
+// dispatchTopicMessages filters OnMessage traffic tagged with the topic
+// prefix into OnTopicMessage, stripping the tag before delivery. Messages
+// without the tag are read from OnMessage and dropped.
+func (t *TopicClient) dispatchTopicMessages() {
+	for {
+		select {
+		case msg, ok := <-t.OnMessage:
+			if !ok {
+				return
+			}
+			topic, data, ok := parseTopicMessage(msg.Data)
+			if !ok {
+				continue
+			}
+			msg.Data = data
+			t.OnTopicMessage <- &TopicMessage{Topic: topic, Message: msg}
+		case <-t.onClose:
+			return
+		}
+	}
+}
+
+func parseTopicMessage(data []byte) (topic string, rest []byte, ok bool) {
+	if !strings.HasPrefix(string(data), topicMessagePrefix) {
+		return "", nil, false
+	}
+	data = data[len(topicMessagePrefix):]
+	idx := indexByte(data, 0)
+	if idx < 0 {
+		return "", nil, false
+	}
+	return string(data[:idx]), data[idx+1:], true
+}
+
+func indexByte(data []byte, b byte) int {
+	for i, c := range data {
+		if c == b {
+			return i
+		}
+	}
+	return -1
+}
+
+// expiresAt approximates when sub's on-chain lease expires, converting its
+// duration in blocks to wall-clock time via nknBlockGenerationInterval.
+func (sub *topicSubscription) expiresAt() time.Time {
+	return sub.joinedAt.Add(time.Duration(sub.duration) * nknBlockGenerationInterval)
+}
+
+// needsRefresh reports whether sub is within subscriptionRefreshMargin
+// blocks of its approximate expiry.
+func (sub *topicSubscription) needsRefresh() bool {
+	margin := time.Duration(subscriptionRefreshMargin) * nknBlockGenerationInterval
+	return time.Until(sub.expiresAt()) <= margin
+}
+
+// refreshSubscriptions re-subscribes joined topics shortly before their
+// on-chain lease expires, using the bucket and meta they were originally
+// joined with. It polls every subscriptionRefreshCheckInterval but only
+// issues an on-chain Subscribe transaction for a topic once it is actually
+// within subscriptionRefreshMargin blocks of expiring.
+func (t *TopicClient) refreshSubscriptions() {
+	ticker := time.NewTicker(subscriptionRefreshCheckInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			t.Lock()
+			subs := make(map[string]*topicSubscription, len(t.joined))
+			for topic, sub := range t.joined {
+				if sub.needsRefresh() {
+					subs[topic] = sub
+				}
+			}
+			t.Unlock()
+
+			for topic, sub := range subs {
+				if _, err := t.wallet.Subscribe(sub.identifier, topic, sub.bucket, sub.duration, sub.meta); err != nil {
+					continue
+				}
+				t.Lock()
+				if existing, ok := t.joined[topic]; ok && existing == sub {
+					existing.joinedAt = time.Now()
+				}
+				t.Unlock()
+			}
+		case <-t.onClose:
+			return
+		}
+	}
+}
+
+// Close stops the TopicClient's background goroutines and the underlying
+// MultiClient. It is safe to call more than once.
+func (t *TopicClient) Close() error {
+	t.Lock()
+	if t.closed {
+		t.Unlock()
+		return nil
+	}
+	t.closed = true
+	t.Unlock()
+
+	close(t.onClose)
+	return t.MultiClient.Close()
+}
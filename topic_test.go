@@ -0,0 +1,41 @@
+package nkn_sdk_go
+
+import (
+	"testing"
+	"time"
+)
+
+func TestTopicSubscriptionNeedsRefreshNearExpiry(t *testing.T) {
+	sub := &topicSubscription{
+		duration: subscriptionRefreshMargin,
+		joinedAt: time.Now().Add(-time.Duration(subscriptionRefreshMargin) * nknBlockGenerationInterval),
+	}
+	if !sub.needsRefresh() {
+		t.Fatal("expected a subscription right at its margin to need refresh")
+	}
+}
+
+func TestTopicSubscriptionDoesNotNeedRefreshWhenFresh(t *testing.T) {
+	sub := &topicSubscription{
+		duration: 1000,
+		joinedAt: time.Now(),
+	}
+	if sub.needsRefresh() {
+		t.Fatal("expected a freshly joined, long-duration subscription not to need refresh yet")
+	}
+}
+
+func TestTopicClientCloseIsIdempotent(t *testing.T) {
+	tc := &TopicClient{
+		MultiClient: &MultiClient{onClose: make(chan struct{})},
+		joined:      make(map[string]*topicSubscription),
+		onClose:     make(chan struct{}),
+	}
+
+	if err := tc.Close(); err != nil {
+		t.Fatalf("first Close returned error: %v", err)
+	}
+	if err := tc.Close(); err != nil {
+		t.Fatalf("second Close should be a no-op, got error: %v", err)
+	}
+}